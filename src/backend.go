@@ -0,0 +1,60 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2019, Guillaume Ballet
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// * Neither the name of the copyright holder nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package pcsc
+
+// Backend is the set of PC/SC operations that every platform-specific
+// transport must provide. New() picks the implementation matching
+// runtime.GOOS: PCSCDClient (pcsc_unix.go) on Linux and macOS, and the
+// WinSCard-backed client (winscard_windows.go) on Windows.
+type Backend interface {
+	// SCardEstablishContext opens a session with the resource manager.
+	SCardEstablishContext(scope uint32) error
+
+	// SCardReleaseContext closes the session opened by SCardEstablishContext.
+	SCardReleaseContext() error
+
+	// SCardListReaders refreshes the list of known readers and their state.
+	SCardListReaders() error
+}
+
+// CardBackend is implemented by every Backend in addition to
+// SCardEstablishContext/SCardReleaseContext/SCardListReaders, exposing
+// the operations needed to actually exchange APDUs with a card once a
+// reader has been picked.
+type CardBackend interface {
+	Backend
+
+	SCardConnect(reader string, shareMode, preferredProtocols uint32) (handle uint32, activeProtocol uint32, err error)
+	SCardDisconnect(handle, disposition uint32) error
+	SCardBeginTransaction(handle uint32) error
+	SCardEndTransaction(handle, disposition uint32) error
+	SCardTransmit(handle uint32, apdu []byte) (response []byte, sw1, sw2 byte, err error)
+}
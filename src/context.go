@@ -0,0 +1,78 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2019, Guillaume Ballet
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// * Neither the name of the copyright holder nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+//go:build linux || darwin
+
+package pcsc
+
+import (
+	"context"
+	"net"
+)
+
+// watchContext ties ctx's lifetime to conn: ctx's deadline, if any, is
+// applied as conn's read deadline, and cancelling ctx closes conn so
+// that a blocked conn.Read returns instead of hanging forever. The
+// returned stop func must be deferred by the caller to stop watching
+// once its own call on conn has returned.
+func watchContext(ctx context.Context, conn net.Conn) (stop func()) {
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetReadDeadline(deadline)
+	}
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
+
+// scardCancel asks pcscd to unblock whatever SCardTransmit is currently
+// pending on client's context, the way SCardTransmitCtx cancels a
+// transaction instead of tearing down the connection outright. Like
+// WatchReaderStates' cancellation, this requires its own connection:
+// client.conn's handler thread won't read the cancel message until it
+// finishes replying to the SCardTransmit it is already blocked on. It
+// does not wait for pcscd's reply; the pending SCardTransmit's own read
+// unblocks once pcscd answers it with SCARD_E_CANCELLED.
+func (client *PCSCDClient) scardCancel() {
+	cancelConn, err := clientSetupSession()
+	if err != nil {
+		return
+	}
+	defer cancelConn.Close()
+
+	payload := make([]byte, 8)
+	nativeByteOrder.PutUint32(payload, client.ctx)
+	_ = messageSendWithHeader(SCardCancel, cancelConn, payload)
+}
@@ -31,9 +31,7 @@
 package pcsc
 
 const (
-	SCardSuccess                   = 0x00000000 /* No error was encountered. */
-
-	PCSCDSockName = "/run/pcscd/pcscd.comm"
+	SCardSuccess = 0x00000000 /* No error was encountered. */
 )
 
 // List of commands to send to the daemon
@@ -0,0 +1,144 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2019, Guillaume Ballet
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// * Neither the name of the copyright holder nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package pcsc
+
+import "fmt"
+
+// Error is a pcsc-lite return code. It is returned, unwrapped, by every
+// request/response helper in this package, so callers can match it with
+// errors.Is against the Err* sentinels below (e.g.
+// errors.Is(err, pcsc.ErrRemovedCard)).
+type Error uint32
+
+// Error implements the error interface, reproducing the stringification
+// pcsc-lite itself uses for these codes (see PCSC/error.h).
+func (e Error) Error() string {
+	if s, ok := errorStrings[e]; ok {
+		return s
+	}
+	return fmt.Sprintf("unknown pcsc error: %#x", uint32(e))
+}
+
+// SCARD_E_*, SCARD_F_* and SCARD_W_* return codes, as defined by
+// pcsc-lite's winscard.h.
+const (
+	ErrSuccess Error = 0x00000000 /* SCARD_S_SUCCESS */
+
+	ErrInternalError      Error = 0x80100001 /* SCARD_F_INTERNAL_ERROR */
+	ErrCancelled          Error = 0x80100002 /* SCARD_E_CANCELLED */
+	ErrInvalidHandle      Error = 0x80100003 /* SCARD_E_INVALID_HANDLE */
+	ErrInvalidParameter   Error = 0x80100004 /* SCARD_E_INVALID_PARAMETER */
+	ErrInvalidTarget      Error = 0x80100005 /* SCARD_E_INVALID_TARGET */
+	ErrNoMemory           Error = 0x80100006 /* SCARD_E_NO_MEMORY */
+	ErrWaitedTooLong      Error = 0x80100007 /* SCARD_F_WAITED_TOO_LONG */
+	ErrInsufficientBuffer Error = 0x80100008 /* SCARD_E_INSUFFICIENT_BUFFER */
+	ErrUnknownReader      Error = 0x80100009 /* SCARD_E_UNKNOWN_READER */
+	ErrTimeout            Error = 0x8010000A /* SCARD_E_TIMEOUT */
+	ErrSharingViolation   Error = 0x8010000B /* SCARD_E_SHARING_VIOLATION */
+	ErrNoSmartcard        Error = 0x8010000C /* SCARD_E_NO_SMARTCARD */
+	ErrUnknownCard        Error = 0x8010000D /* SCARD_E_UNKNOWN_CARD */
+	ErrCantDispose        Error = 0x8010000E /* SCARD_E_CANT_DISPOSE */
+	ErrProtoMismatch      Error = 0x8010000F /* SCARD_E_PROTO_MISMATCH */
+	ErrNotReady           Error = 0x80100010 /* SCARD_E_NOT_READY */
+	ErrInvalidValue       Error = 0x80100011 /* SCARD_E_INVALID_VALUE */
+	ErrSystemCancelled    Error = 0x80100012 /* SCARD_E_SYSTEM_CANCELLED */
+	ErrCommError          Error = 0x80100013 /* SCARD_F_COMM_ERROR */
+	ErrUnknownError       Error = 0x80100014 /* SCARD_F_UNKNOWN_ERROR */
+	ErrInvalidAtr         Error = 0x80100015 /* SCARD_E_INVALID_ATR */
+	ErrNotTransacted      Error = 0x80100016 /* SCARD_E_NOT_TRANSACTED */
+	ErrReaderUnavailable  Error = 0x80100017 /* SCARD_E_READER_UNAVAILABLE */
+	ErrReaderUnsupported  Error = 0x8010001A /* SCARD_E_READER_UNSUPPORTED */
+	ErrDuplicateReader    Error = 0x8010001B /* SCARD_E_DUPLICATE_READER */
+	ErrCardUnsupported    Error = 0x8010001C /* SCARD_E_CARD_UNSUPPORTED */
+	ErrNoService          Error = 0x8010001D /* SCARD_E_NO_SERVICE */
+	ErrServiceStopped     Error = 0x8010001E /* SCARD_E_SERVICE_STOPPED */
+	ErrNoReadersAvailable Error = 0x8010002E /* SCARD_E_NO_READERS_AVAILABLE */
+	ErrServerTooBusy      Error = 0x80100031 /* SCARD_E_SERVER_TOO_BUSY */
+
+	ErrUnsupportedCard      Error = 0x80100065 /* SCARD_W_UNSUPPORTED_CARD */
+	ErrUnresponsiveCard     Error = 0x80100066 /* SCARD_W_UNRESPONSIVE_CARD */
+	ErrUnpoweredCard        Error = 0x80100067 /* SCARD_W_UNPOWERED_CARD */
+	ErrResetCard            Error = 0x80100068 /* SCARD_W_RESET_CARD */
+	ErrRemovedCard          Error = 0x80100069 /* SCARD_W_REMOVED_CARD */
+	ErrSecurityViolation    Error = 0x8010006A /* SCARD_W_SECURITY_VIOLATION */
+	ErrWrongChv             Error = 0x8010006B /* SCARD_W_WRONG_CHV */
+	ErrChvBlocked           Error = 0x8010006C /* SCARD_W_CHV_BLOCKED */
+	ErrEOF                  Error = 0x8010006D /* SCARD_W_EOF */
+	ErrCancelledByUser      Error = 0x8010006E /* SCARD_W_CANCELLED_BY_USER */
+	ErrCardNotAuthenticated Error = 0x8010006F /* SCARD_W_CARD_NOT_AUTHENTICATED */
+)
+
+var errorStrings = map[Error]string{
+	ErrSuccess: "SCARD_S_SUCCESS",
+
+	ErrInternalError:      "SCARD_F_INTERNAL_ERROR",
+	ErrCancelled:          "SCARD_E_CANCELLED",
+	ErrInvalidHandle:      "SCARD_E_INVALID_HANDLE",
+	ErrInvalidParameter:   "SCARD_E_INVALID_PARAMETER",
+	ErrInvalidTarget:      "SCARD_E_INVALID_TARGET",
+	ErrNoMemory:           "SCARD_E_NO_MEMORY",
+	ErrWaitedTooLong:      "SCARD_F_WAITED_TOO_LONG",
+	ErrInsufficientBuffer: "SCARD_E_INSUFFICIENT_BUFFER",
+	ErrUnknownReader:      "SCARD_E_UNKNOWN_READER",
+	ErrTimeout:            "SCARD_E_TIMEOUT",
+	ErrSharingViolation:   "SCARD_E_SHARING_VIOLATION",
+	ErrNoSmartcard:        "SCARD_E_NO_SMARTCARD",
+	ErrUnknownCard:        "SCARD_E_UNKNOWN_CARD",
+	ErrCantDispose:        "SCARD_E_CANT_DISPOSE",
+	ErrProtoMismatch:      "SCARD_E_PROTO_MISMATCH",
+	ErrNotReady:           "SCARD_E_NOT_READY",
+	ErrInvalidValue:       "SCARD_E_INVALID_VALUE",
+	ErrSystemCancelled:    "SCARD_E_SYSTEM_CANCELLED",
+	ErrCommError:          "SCARD_F_COMM_ERROR",
+	ErrUnknownError:       "SCARD_F_UNKNOWN_ERROR",
+	ErrInvalidAtr:         "SCARD_E_INVALID_ATR",
+	ErrNotTransacted:      "SCARD_E_NOT_TRANSACTED",
+	ErrReaderUnavailable:  "SCARD_E_READER_UNAVAILABLE",
+	ErrReaderUnsupported:  "SCARD_E_READER_UNSUPPORTED",
+	ErrDuplicateReader:    "SCARD_E_DUPLICATE_READER",
+	ErrCardUnsupported:    "SCARD_E_CARD_UNSUPPORTED",
+	ErrNoService:          "SCARD_E_NO_SERVICE",
+	ErrServiceStopped:     "SCARD_E_SERVICE_STOPPED",
+	ErrNoReadersAvailable: "SCARD_E_NO_READERS_AVAILABLE",
+	ErrServerTooBusy:      "SCARD_E_SERVER_TOO_BUSY",
+
+	ErrUnsupportedCard:      "SCARD_W_UNSUPPORTED_CARD",
+	ErrUnresponsiveCard:     "SCARD_W_UNRESPONSIVE_CARD",
+	ErrUnpoweredCard:        "SCARD_W_UNPOWERED_CARD",
+	ErrResetCard:            "SCARD_W_RESET_CARD",
+	ErrRemovedCard:          "SCARD_W_REMOVED_CARD",
+	ErrSecurityViolation:    "SCARD_W_SECURITY_VIOLATION",
+	ErrWrongChv:             "SCARD_W_WRONG_CHV",
+	ErrChvBlocked:           "SCARD_W_CHV_BLOCKED",
+	ErrEOF:                  "SCARD_W_EOF",
+	ErrCancelledByUser:      "SCARD_W_CANCELLED_BY_USER",
+	ErrCardNotAuthenticated: "SCARD_W_CARD_NOT_AUTHENTICATED",
+}
@@ -0,0 +1,53 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2019, Guillaume Ballet
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// * Neither the name of the copyright holder nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+//go:build darwin
+
+// macOS has no built-in pcscd; this package does not bind PCSC.framework
+// directly (that would require cgo), so it falls back to whatever
+// pcsc-lite installation is present, e.g. the one Homebrew's
+// pcsc-lite formula installs and starts as a launchd daemon.
+package pcsc
+
+import (
+	"fmt"
+	"net"
+	"os"
+)
+
+// clientSetupSession dials pcscd's Unix domain socket if pcsc-lite is
+// installed on this Mac, and fails clearly otherwise instead of hanging
+// on a socket that will never be created.
+func clientSetupSession() (net.Conn, error) {
+	if _, err := os.Stat(PCSCDSockName); err != nil {
+		return nil, fmt.Errorf("pcscd socket not found at %s: install pcsc-lite (e.g. `brew install pcsc-lite`) or set PCSCLITE_CSOCK_NAME; binding PCSC.framework directly is not implemented: %w", PCSCDSockName, err)
+	}
+	return net.Dial("unix", PCSCDSockName)
+}
@@ -0,0 +1,408 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2019, Guillaume Ballet
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// * Neither the name of the copyright holder nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+//go:build linux || darwin
+
+// PCSCDClient talks to pcscd over its Unix domain socket. This is the
+// transport used on Linux, and on macOS when pcsc-lite's pcscd is
+// available (e.g. installed through Homebrew) rather than binding
+// PCSC.framework directly.
+package pcsc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+)
+
+// PCSCDClient is the Backend implementation that speaks the pcscd wire
+// protocol over a Unix domain socket.
+type PCSCDClient struct {
+	conn net.Conn
+
+	minor uint32
+	major uint32
+
+	ctx uint32
+
+	// activeProtocol is the protocol SCardConnect negotiated with the
+	// card, needed to populate pioSendPci on every SCardTransmit.
+	activeProtocol uint32
+
+	readerStateDescriptors [MaxReaderStateDescriptors]readerState
+}
+
+var _ Backend = (*PCSCDClient)(nil)
+var _ CardBackend = (*PCSCDClient)(nil)
+
+// New returns a Backend appropriate for the current platform. On Linux
+// and macOS, it is a PCSCDClient for the pcscd daemon; the connection
+// itself is opened by SCardEstablishContext.
+func New() *PCSCDClient {
+	return &PCSCDClient{}
+}
+
+// SCardEstablishContext is SCardEstablishContextCtx with a background
+// context, i.e. it blocks until pcscd answers or the connection breaks.
+func (client *PCSCDClient) SCardEstablishContext(scope uint32) error {
+	return client.SCardEstablishContextCtx(context.Background(), scope)
+}
+
+// SCardEstablishContextCtx is SCardEstablishContext, except that ctx's
+// deadline is applied to the underlying socket and cancelling ctx aborts
+// the handshake by closing the connection.
+func (client *PCSCDClient) SCardEstablishContextCtx(ctx context.Context, scope uint32) error {
+	conn, err := clientSetupSession()
+	if err != nil {
+		return err
+	}
+	client.conn = conn
+	defer watchContext(ctx, conn)()
+
+	/* Exchange version information */
+	payload := make([]byte, 12)
+	nativeByteOrder.PutUint32(payload, ProtocolVersionMajor)
+	nativeByteOrder.PutUint32(payload[4:], ProtocolVersionMinor)
+	nativeByteOrder.PutUint32(payload[8:], SCardSuccess)
+	err = messageSendWithHeader(CommandVersion, conn, payload)
+	if err != nil {
+		return err
+	}
+	response := make([]byte, 12)
+	n, err := conn.Read(response)
+	if err != nil {
+		return err
+	}
+	if n != len(response) {
+		return fmt.Errorf("invalid response length: expected %d, got %d", len(response), n)
+	}
+	code := nativeByteOrder.Uint32(response[8:])
+	if code != SCardSuccess {
+		return Error(code)
+	}
+	client.major = nativeByteOrder.Uint32(response)
+	client.minor = nativeByteOrder.Uint32(response[4:])
+	if client.major != ProtocolVersionMajor || client.minor != ProtocolVersionMinor {
+		return fmt.Errorf("invalid version found: expected %d.%d, got %d.%d", ProtocolVersionMajor, ProtocolVersionMinor, client.major, client.minor)
+	}
+
+	/* Establish the context proper */
+	nativeByteOrder.PutUint32(payload, scope)
+	nativeByteOrder.PutUint32(payload[4:], 0)
+	nativeByteOrder.PutUint32(payload[8:], SCardSuccess)
+	err = messageSendWithHeader(SCardEstablishContext, conn, payload)
+	if err != nil {
+		return err
+	}
+	response = make([]byte, 12)
+	n, err = conn.Read(response)
+	if err != nil {
+		return err
+	}
+	if n != len(response) {
+		return fmt.Errorf("invalid response length: expected %d, got %d", len(response), n)
+	}
+	code = nativeByteOrder.Uint32(response[8:])
+	if code != SCardSuccess {
+		return Error(code)
+	}
+	client.ctx = nativeByteOrder.Uint32(response[4:])
+
+	return nil
+}
+
+func (client *PCSCDClient) SCardReleaseContext() error {
+	data := [8]byte{}
+	nativeByteOrder.PutUint32(data[:], client.ctx)
+	nativeByteOrder.PutUint32(data[4:], SCardSuccess)
+	err := messageSendWithHeader(SCardReleaseContext, client.conn, data[:])
+	if err != nil {
+		return err
+	}
+	if err := readFull(client.conn, data[:]); err != nil {
+		return err
+	}
+	code := nativeByteOrder.Uint32(data[4:])
+	if code != SCardSuccess {
+		return Error(code)
+	}
+
+	return nil
+}
+
+// Constants mirroring pcsclite's connect_struct, transmit_struct,
+// disconnect_struct, begin_struct and end_struct layouts.
+const (
+	MaxReaderNameLength = ReaderStateNameLength
+
+	connectStructLength    = 4 + MaxReaderNameLength + 4 + 4 + 4 + 4 + 4
+	transmitStructLength   = 4 + 4 + 4 + MaxBufferSize + 4 + 4 + 4 + MaxBufferSize + 4 + 4
+	disconnectStructLength = 4 + 4 + 4
+	beginStructLength      = 4 + 4
+	endStructLength        = 4 + 4 + 4
+)
+
+// SCardConnect establishes a connection to the card in the named reader
+// and returns the card handle together with the protocol negotiated
+// with it.
+func (client *PCSCDClient) SCardConnect(reader string, shareMode, preferredProtocols uint32) (uint32, uint32, error) {
+	payload := make([]byte, connectStructLength)
+	nativeByteOrder.PutUint32(payload, client.ctx)
+	copy(payload[4:], reader)
+	nativeByteOrder.PutUint32(payload[4+MaxReaderNameLength:], shareMode)
+	nativeByteOrder.PutUint32(payload[4+MaxReaderNameLength+4:], preferredProtocols)
+
+	if err := messageSendWithHeader(SCardConnect, client.conn, payload); err != nil {
+		return 0, 0, err
+	}
+	response := make([]byte, connectStructLength)
+	if err := readFull(client.conn, response); err != nil {
+		return 0, 0, err
+	}
+	code := nativeByteOrder.Uint32(response[connectStructLength-4:])
+	if code != SCardSuccess {
+		return 0, 0, Error(code)
+	}
+	handle := nativeByteOrder.Uint32(response[4+MaxReaderNameLength+8:])
+	activeProtocol := nativeByteOrder.Uint32(response[4+MaxReaderNameLength+12:])
+	client.activeProtocol = activeProtocol
+
+	return handle, activeProtocol, nil
+}
+
+// SCardDisconnect terminates a connection opened with SCardConnect,
+// applying the given disposition (e.g. SCardLeaveCard, SCardResetCard)
+// to the card.
+func (client *PCSCDClient) SCardDisconnect(handle, disposition uint32) error {
+	payload := make([]byte, disconnectStructLength)
+	nativeByteOrder.PutUint32(payload, handle)
+	nativeByteOrder.PutUint32(payload[4:], disposition)
+
+	if err := messageSendWithHeader(SCardDisConnect, client.conn, payload); err != nil {
+		return err
+	}
+	response := make([]byte, disconnectStructLength)
+	if err := readFull(client.conn, response); err != nil {
+		return err
+	}
+	code := nativeByteOrder.Uint32(response[8:])
+	if code != SCardSuccess {
+		return Error(code)
+	}
+
+	return nil
+}
+
+// SCardBeginTransaction requests exclusive access to the card so that a
+// sequence of SCardTransmit calls is not interleaved with another
+// application's.
+func (client *PCSCDClient) SCardBeginTransaction(handle uint32) error {
+	payload := make([]byte, beginStructLength)
+	nativeByteOrder.PutUint32(payload, handle)
+
+	if err := messageSendWithHeader(SCardBeginTransaction, client.conn, payload); err != nil {
+		return err
+	}
+	response := make([]byte, beginStructLength)
+	if err := readFull(client.conn, response); err != nil {
+		return err
+	}
+	code := nativeByteOrder.Uint32(response[4:])
+	if code != SCardSuccess {
+		return Error(code)
+	}
+
+	return nil
+}
+
+// SCardEndTransaction releases the exclusive access acquired with
+// SCardBeginTransaction.
+func (client *PCSCDClient) SCardEndTransaction(handle, disposition uint32) error {
+	payload := make([]byte, endStructLength)
+	nativeByteOrder.PutUint32(payload, handle)
+	nativeByteOrder.PutUint32(payload[4:], disposition)
+
+	if err := messageSendWithHeader(SCardEndTransaction, client.conn, payload); err != nil {
+		return err
+	}
+	response := make([]byte, endStructLength)
+	if err := readFull(client.conn, response); err != nil {
+		return err
+	}
+	code := nativeByteOrder.Uint32(response[8:])
+	if code != SCardSuccess {
+		return Error(code)
+	}
+
+	return nil
+}
+
+// SCardTransmit is SCardTransmitCtx with a background context.
+func (client *PCSCDClient) SCardTransmit(handle uint32, apdu []byte) ([]byte, byte, byte, error) {
+	return client.SCardTransmitCtx(context.Background(), handle, apdu)
+}
+
+// SCardTransmitCtx sends an APDU to the card over the given handle and
+// returns the card's response, stripped of the trailing SW1/SW2 status
+// bytes which are returned separately. ctx's deadline is applied to the
+// underlying socket, and cancelling ctx sends SCardCancel so that a card
+// still negotiating doesn't block the caller forever.
+func (client *PCSCDClient) SCardTransmitCtx(ctx context.Context, handle uint32, apdu []byte) ([]byte, byte, byte, error) {
+	if len(apdu) > MaxBufferSize {
+		return nil, 0, 0, fmt.Errorf("apdu too long: %d > %d", len(apdu), MaxBufferSize)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		client.conn.SetReadDeadline(deadline)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			client.scardCancel()
+		case <-done:
+		}
+	}()
+
+	payload := make([]byte, transmitStructLength)
+	nativeByteOrder.PutUint32(payload, handle)
+	nativeByteOrder.PutUint32(payload[4:], client.activeProtocol)
+	nativeByteOrder.PutUint32(payload[8:], 8) // cbPciLength: sizeof(dwProtocol) + sizeof(cbPciLength)
+	copy(payload[12:], apdu)
+	nativeByteOrder.PutUint32(payload[12+MaxBufferSize:], uint32(len(apdu)))
+
+	if err := messageSendWithHeader(SCardTransmit, client.conn, payload); err != nil {
+		return nil, 0, 0, err
+	}
+	response := make([]byte, transmitStructLength)
+	if err := readFull(client.conn, response); err != nil {
+		return nil, 0, 0, err
+	}
+	code := nativeByteOrder.Uint32(response[transmitStructLength-4:])
+	if code != SCardSuccess {
+		return nil, 0, 0, Error(code)
+	}
+
+	recvOffset := 12 + MaxBufferSize + 4 + 4 + 4
+	recvLength := nativeByteOrder.Uint32(response[recvOffset+MaxBufferSize:])
+	if recvLength < 2 || int(recvLength) > MaxBufferSize {
+		return nil, 0, 0, fmt.Errorf("invalid response length: %d", recvLength)
+	}
+	recvBuffer := response[recvOffset : recvOffset+int(recvLength)]
+
+	return recvBuffer[:len(recvBuffer)-2], recvBuffer[len(recvBuffer)-2], recvBuffer[len(recvBuffer)-1], nil
+}
+
+// readFull reads exactly len(buf) bytes from conn, the way pcscd's
+// fixed-size replies need to be consumed.
+func readFull(conn net.Conn, buf []byte) error {
+	total := 0
+	for total < len(buf) {
+		n, err := conn.Read(buf[total:])
+		if err != nil {
+			return err
+		}
+		total += n
+	}
+	return nil
+}
+
+// Byte offsets of each field within a reader_state descriptor, as laid
+// out by pcsclite (name, then three state uint32s, then the 32-bit
+// aligned ATR, then its length and the negotiated protocol). These are
+// an explicit, packed mirror of the C layout rather than something
+// derived from this Go struct's own field offsets, which depend on the
+// Go compiler's alignment choices and say nothing about the bytes pcscd
+// actually sends.
+const (
+	readerStateNameOffset         = 0
+	readerStateEventCounterOffset = readerStateNameOffset + ReaderStateNameLength
+	readerStateStateOffset        = readerStateEventCounterOffset + 4
+	readerStateSharingOffset      = readerStateStateOffset + 4
+	readerStateAtrOffset          = readerStateSharingOffset + 4
+	readerStateAtrLengthOffset    = readerStateAtrOffset + ReaderStateMaxAtrSizeLength + 3 /* padding to keep the ATR 32-bit aligned */
+	readerStateProtocolOffset     = readerStateAtrLengthOffset + 4
+)
+
+func getReaderState(data []byte) (readerState, error) {
+	ret := readerState{}
+	if len(data) < ReaderStateDescriptorLength {
+		return ret, fmt.Errorf("could not unmarshall data of length %d < %d", len(data), ReaderStateDescriptorLength)
+	}
+
+	name := data[readerStateNameOffset:readerStateEventCounterOffset]
+	if i := bytes.IndexByte(name, 0); i >= 0 {
+		name = name[:i]
+	}
+	ret.name = string(name)
+	ret.eventCounter = nativeByteOrder.Uint32(data[readerStateEventCounterOffset:])
+	ret.readerState = nativeByteOrder.Uint32(data[readerStateStateOffset:])
+	ret.readerSharing = nativeByteOrder.Uint32(data[readerStateSharingOffset:])
+	ret.cardAtrLength = nativeByteOrder.Uint32(data[readerStateAtrLengthOffset:])
+	if ret.cardAtrLength > ReaderStateMaxAtrSizeLength {
+		return readerState{}, fmt.Errorf("invalid ATR length: %d > %d", ret.cardAtrLength, ReaderStateMaxAtrSizeLength)
+	}
+	copy(ret.cardAtr[:], data[readerStateAtrOffset:readerStateAtrOffset+ReaderStateMaxAtrSizeLength])
+	ret.cardProtocol = nativeByteOrder.Uint32(data[readerStateProtocolOffset:])
+
+	return ret, nil
+}
+
+// SCardListReaders is SCardListReadersCtx with a background context.
+func (client *PCSCDClient) SCardListReaders() error {
+	return client.SCardListReadersCtx(context.Background())
+}
+
+// SCardListReadersCtx gets the list of readers from the daemon, honoring
+// ctx's deadline and aborting the read if ctx is cancelled.
+func (client *PCSCDClient) SCardListReadersCtx(ctx context.Context) error {
+	defer watchContext(ctx, client.conn)()
+
+	err := messageSendWithHeader(CommandGetReaderState, client.conn, []byte{})
+	if err != nil {
+		return err
+	}
+	response := make([]byte, ReaderStateDescriptorLength*MaxReaderStateDescriptors)
+	if err := readFull(client.conn, response); err != nil {
+		return err
+	}
+
+	for i := range client.readerStateDescriptors {
+		desc, err := getReaderState(response[i*ReaderStateDescriptorLength:])
+		if err != nil {
+			return err
+		}
+		client.readerStateDescriptors[i] = desc
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2019, Guillaume Ballet
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// * Neither the name of the copyright holder nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+//go:build linux || darwin
+
+package pcsc
+
+import "context"
+
+// SCARD_STATE_* bits reported in a reader state descriptor, describing
+// what pcscd currently knows about a reader and the card inside it.
+const (
+	SCardStateUnaware     = 0x00000000
+	SCardStateIgnore      = 0x00000001
+	SCardStateChanged     = 0x00000002
+	SCardStateUnknown     = 0x00000004
+	SCardStateUnavailable = 0x00000008
+	SCardStateEmpty       = 0x00000010
+	SCardStatePresent     = 0x00000020
+	SCardStateAtrmatch    = 0x00000040
+	SCardStateExclusive   = 0x00000080
+	SCardStateInuse       = 0x00000100
+	SCardStateMute        = 0x00000200
+	SCardStateUnpowered   = 0x00000400
+)
+
+// ReaderStateEventType identifies the kind of change WatchReaderStates
+// reports for a reader.
+type ReaderStateEventType int
+
+const (
+	CardInserted ReaderStateEventType = iota
+	CardRemoved
+	ReaderAdded
+	ReaderRemoved
+	StateChanged
+)
+
+// ReaderStateEvent describes a single change observed on one reader
+// between two wakeups of the pcscd reader-state-change notification.
+type ReaderStateEvent struct {
+	Reader string
+	Type   ReaderStateEventType
+	State  uint32
+	Atr    []byte
+}
+
+// WatchReaderStates streams reader and card state changes as they are
+// reported by pcscd, so callers don't have to poll SCardListReaders.
+// The returned channel is closed once ctx is done or the watch fails.
+func (client *PCSCDClient) WatchReaderStates(ctx context.Context) (<-chan ReaderStateEvent, error) {
+	// pcscd requires the cancellation request to come in on a connection
+	// other than the one blocked in CommandWaitReaderStateChange.
+	cancelConn, err := clientSetupSession()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan ReaderStateEvent)
+
+	go func() {
+		<-ctx.Done()
+		_ = messageSendWithHeader(CommandStopWaitingReaderStateChange, cancelConn, []byte{})
+		cancelConn.Close()
+	}()
+
+	go func() {
+		defer close(events)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			if err := messageSendWithHeader(CommandWaitReaderStateChange, client.conn, []byte{}); err != nil {
+				return
+			}
+			response := make([]byte, ReaderStateDescriptorLength*MaxReaderStateDescriptors)
+			if err := readFull(client.conn, response); err != nil {
+				return
+			}
+
+			for i := range client.readerStateDescriptors {
+				next, err := getReaderState(response[i*ReaderStateDescriptorLength:])
+				if err != nil {
+					return
+				}
+				prev := client.readerStateDescriptors[i]
+				client.readerStateDescriptors[i] = next
+
+				for _, event := range diffReaderState(prev, next) {
+					select {
+					case events <- event:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// diffReaderState compares two snapshots of the same reader slot and
+// turns whatever changed into the events WatchReaderStates emits.
+func diffReaderState(prev, next readerState) []ReaderStateEvent {
+	switch {
+	case prev.name == "" && next.name != "":
+		return []ReaderStateEvent{{Reader: next.name, Type: ReaderAdded, State: next.readerState, Atr: next.cardAtr[:next.cardAtrLength]}}
+	case prev.name != "" && next.name == "":
+		return []ReaderStateEvent{{Reader: prev.name, Type: ReaderRemoved, State: prev.readerState}}
+	case prev.name == "" && next.name == "":
+		return nil
+	case prev.eventCounter == next.eventCounter && prev.readerState == next.readerState:
+		return nil
+	}
+
+	wasPresent := prev.readerState&SCardStatePresent != 0
+	isPresent := next.readerState&SCardStatePresent != 0
+	event := ReaderStateEvent{Reader: next.name, State: next.readerState, Atr: next.cardAtr[:next.cardAtrLength]}
+	switch {
+	case !wasPresent && isPresent:
+		event.Type = CardInserted
+	case wasPresent && !isPresent:
+		event.Type = CardRemoved
+	default:
+		event.Type = StateChanged
+	}
+
+	return []ReaderStateEvent{event}
+}
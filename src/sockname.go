@@ -0,0 +1,64 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2019, Guillaume Ballet
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// * Neither the name of the copyright holder nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+//go:build linux || darwin
+
+package pcsc
+
+import (
+	"net"
+	"os"
+)
+
+// PCSCDSockName is the path of the Unix domain socket pcscd listens on.
+// It defaults to the well-known pcsc-lite location, but can be
+// overridden with the PCSCLITE_CSOCK_NAME environment variable, exactly
+// as libpcsclite itself does.
+var PCSCDSockName = defaultPCSCDSockName()
+
+func defaultPCSCDSockName() string {
+	if name := os.Getenv("PCSCLITE_CSOCK_NAME"); name != "" {
+		return name
+	}
+	return "/run/pcscd/pcscd.comm"
+}
+
+// messageSendWithHeader prefixes payload with the pcscd message header
+// (payload size followed by the command opcode) and writes both to conn
+// in a single call, the way pcsc-lite's client does.
+func messageSendWithHeader(command uint32, conn net.Conn, payload []byte) error {
+	message := make([]byte, 8+len(payload))
+	nativeByteOrder.PutUint32(message, uint32(len(payload)))
+	nativeByteOrder.PutUint32(message[4:], command)
+	copy(message[8:], payload)
+
+	_, err := conn.Write(message)
+	return err
+}
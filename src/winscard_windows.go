@@ -0,0 +1,240 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2019, Guillaume Ballet
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// * Neither the name of the copyright holder nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+//go:build windows
+
+// WinSCardClient talks to the Windows Smart Card resource manager through
+// WinSCard.dll, loaded with syscall.NewLazyDLL so that this package keeps
+// building without cgo.
+package pcsc
+
+import (
+	"fmt"
+	"syscall"
+	"unicode/utf16"
+	"unsafe"
+)
+
+var (
+	winscard                  = syscall.NewLazyDLL("WinSCard.dll")
+	procSCardEstablishContext = winscard.NewProc("SCardEstablishContext")
+	procSCardReleaseContext   = winscard.NewProc("SCardReleaseContext")
+	procSCardListReadersW     = winscard.NewProc("SCardListReadersW")
+	procSCardConnectW         = winscard.NewProc("SCardConnectW")
+	procSCardDisconnect       = winscard.NewProc("SCardDisconnect")
+	procSCardBeginTransaction = winscard.NewProc("SCardBeginTransaction")
+	procSCardEndTransaction   = winscard.NewProc("SCardEndTransaction")
+	procSCardTransmit         = winscard.NewProc("SCardTransmit")
+)
+
+// scardIoRequest mirrors WinSCard's SCARD_IO_REQUEST, the protocol
+// control information SCardTransmit takes for the APDU it sends.
+type scardIoRequest struct {
+	dwProtocol  uint32
+	cbPciLength uint32
+}
+
+// WinSCardClient is the Backend implementation backed by the native
+// Windows Smart Card resource manager.
+type WinSCardClient struct {
+	ctx uintptr
+
+	// activeProtocol is the protocol SCardConnect negotiated with the
+	// card, needed to build the SCARD_IO_REQUEST SCardTransmit requires.
+	activeProtocol uint32
+
+	readerStateDescriptors [MaxReaderStateDescriptors]readerState
+}
+
+var _ CardBackend = (*WinSCardClient)(nil)
+
+// New returns a Backend appropriate for the current platform. On
+// Windows, it is a WinSCardClient bound to WinSCard.dll.
+func New() *WinSCardClient {
+	return &WinSCardClient{}
+}
+
+func (client *WinSCardClient) SCardEstablishContext(scope uint32) error {
+	var ctx uintptr
+	ret, _, _ := procSCardEstablishContext.Call(uintptr(scope), 0, 0, uintptr(unsafe.Pointer(&ctx)))
+	if code := uint32(ret); code != SCardSuccess {
+		return Error(code)
+	}
+	client.ctx = ctx
+	return nil
+}
+
+func (client *WinSCardClient) SCardReleaseContext() error {
+	ret, _, _ := procSCardReleaseContext.Call(client.ctx)
+	if code := uint32(ret); code != SCardSuccess {
+		return Error(code)
+	}
+	return nil
+}
+
+// SCardListReaders refreshes readerStateDescriptors from the reader
+// names WinSCard reports. Only the names are filled in; the state,
+// sharing, ATR and protocol fields of every descriptor are left zero.
+func (client *WinSCardClient) SCardListReaders() error {
+	var pcchReaders uint32
+	ret, _, _ := procSCardListReadersW.Call(client.ctx, 0, 0, uintptr(unsafe.Pointer(&pcchReaders)))
+	if code := uint32(ret); code != SCardSuccess {
+		return Error(code)
+	}
+
+	mszReaders := make([]uint16, pcchReaders)
+	ret, _, _ = procSCardListReadersW.Call(client.ctx, 0, uintptr(unsafe.Pointer(&mszReaders[0])), uintptr(unsafe.Pointer(&pcchReaders)))
+	if code := uint32(ret); code != SCardSuccess {
+		return Error(code)
+	}
+
+	// mszReaders is a MULTI_SZ: reader names separated by a single NUL,
+	// the whole list terminated by an extra trailing NUL.
+	names := make([]string, 0, MaxReaderStateDescriptors)
+	for start := 0; start < len(mszReaders); {
+		end := start
+		for end < len(mszReaders) && mszReaders[end] != 0 {
+			end++
+		}
+		if end == start {
+			break
+		}
+		names = append(names, string(utf16.Decode(mszReaders[start:end])))
+		start = end + 1
+	}
+
+	client.readerStateDescriptors = [MaxReaderStateDescriptors]readerState{}
+	for i, name := range names {
+		if i >= len(client.readerStateDescriptors) {
+			break
+		}
+		client.readerStateDescriptors[i].name = name
+	}
+
+	return nil
+}
+
+// SCardConnect establishes a connection to the card in the named reader
+// and returns the card handle together with the protocol negotiated
+// with it.
+func (client *WinSCardClient) SCardConnect(reader string, shareMode, preferredProtocols uint32) (uint32, uint32, error) {
+	readerName, err := syscall.UTF16PtrFromString(reader)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var handle uintptr
+	var activeProtocol uint32
+	ret, _, _ := procSCardConnectW.Call(
+		client.ctx,
+		uintptr(unsafe.Pointer(readerName)),
+		uintptr(shareMode),
+		uintptr(preferredProtocols),
+		uintptr(unsafe.Pointer(&handle)),
+		uintptr(unsafe.Pointer(&activeProtocol)),
+	)
+	if code := uint32(ret); code != SCardSuccess {
+		return 0, 0, Error(code)
+	}
+	client.activeProtocol = activeProtocol
+
+	return uint32(handle), activeProtocol, nil
+}
+
+// SCardDisconnect terminates a connection opened with SCardConnect,
+// applying the given disposition (e.g. SCardLeaveCard, SCardResetCard)
+// to the card.
+func (client *WinSCardClient) SCardDisconnect(handle, disposition uint32) error {
+	ret, _, _ := procSCardDisconnect.Call(uintptr(handle), uintptr(disposition))
+	if code := uint32(ret); code != SCardSuccess {
+		return Error(code)
+	}
+	return nil
+}
+
+// SCardBeginTransaction requests exclusive access to the card so that a
+// sequence of SCardTransmit calls is not interleaved with another
+// application's.
+func (client *WinSCardClient) SCardBeginTransaction(handle uint32) error {
+	ret, _, _ := procSCardBeginTransaction.Call(uintptr(handle))
+	if code := uint32(ret); code != SCardSuccess {
+		return Error(code)
+	}
+	return nil
+}
+
+// SCardEndTransaction releases the exclusive access acquired with
+// SCardBeginTransaction.
+func (client *WinSCardClient) SCardEndTransaction(handle, disposition uint32) error {
+	ret, _, _ := procSCardEndTransaction.Call(uintptr(handle), uintptr(disposition))
+	if code := uint32(ret); code != SCardSuccess {
+		return Error(code)
+	}
+	return nil
+}
+
+// SCardTransmit sends an APDU to the card over the given handle and
+// returns the card's response, stripped of the trailing SW1/SW2 status
+// bytes which are returned separately. The send protocol control
+// information is derived from activeProtocol, as returned by
+// SCardConnect; no receive protocol control information is requested.
+func (client *WinSCardClient) SCardTransmit(handle uint32, apdu []byte) ([]byte, byte, byte, error) {
+	if len(apdu) > MaxBufferSize {
+		return nil, 0, 0, fmt.Errorf("apdu too long: %d > %d", len(apdu), MaxBufferSize)
+	}
+
+	sendPci := scardIoRequest{dwProtocol: client.activeProtocol, cbPciLength: uint32(unsafe.Sizeof(scardIoRequest{}))}
+	recvBuffer := make([]byte, MaxBufferSize)
+	recvLength := uint32(len(recvBuffer))
+
+	var sendBuffer unsafe.Pointer
+	if len(apdu) > 0 {
+		sendBuffer = unsafe.Pointer(&apdu[0])
+	}
+
+	ret, _, _ := procSCardTransmit.Call(
+		uintptr(handle),
+		uintptr(unsafe.Pointer(&sendPci)),
+		uintptr(sendBuffer),
+		uintptr(len(apdu)),
+		0,
+		uintptr(unsafe.Pointer(&recvBuffer[0])),
+		uintptr(unsafe.Pointer(&recvLength)),
+	)
+	if code := uint32(ret); code != SCardSuccess {
+		return nil, 0, 0, Error(code)
+	}
+	if recvLength < 2 || int(recvLength) > len(recvBuffer) {
+		return nil, 0, 0, fmt.Errorf("invalid response length: %d", recvLength)
+	}
+	response := recvBuffer[:recvLength]
+
+	return response[:len(response)-2], response[len(response)-2], response[len(response)-1], nil
+}